@@ -0,0 +1,51 @@
+// Package backend abstracts the storage system docsheep scans for
+// incoming PDFs and writes processed results to, so the OCR pipeline
+// does not need to know whether it is talking to Google Drive, a local
+// directory, or S3.
+package backend
+
+import (
+	"context"
+	"time"
+)
+
+// Item describes a single file discovered in the incoming queue.
+type Item struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+// Backend is implemented once per storage driver. Concrete
+// implementations live in gdrive.go, local.go and s3.go.
+type Backend interface {
+	// ListIncoming returns the PDFs waiting to be processed.
+	ListIncoming() ([]Item, error)
+
+	// Fetch downloads the raw PDF for item to a local path and returns it.
+	// Callers are responsible for removing the file once done with it.
+	Fetch(item Item) (localPath string, err error)
+
+	// PutProcessed stores the processed, searchable PDF at localPDFPath
+	// under the given title/description and returns a backend-specific
+	// identifier for the stored file.
+	PutProcessed(title, description, localPDFPath string) (string, error)
+
+	// MarkDone marks item as handled so ListIncoming no longer returns it.
+	MarkDone(item Item) error
+}
+
+// Watchable is implemented by backends that can notify -watch mode about
+// newly-arrived items as they show up, instead of being polled. Watch
+// blocks, sending each new Item to out, until ctx is cancelled.
+// Backends without a native notification mechanism (S3) don't implement
+// this; -watch mode falls back to periodically calling ListIncoming.
+type Watchable interface {
+	Watch(ctx context.Context, out chan<- Item) error
+}
+
+// New constructs a Backend from a "--backend" flag value such as
+// "gdrive", "local:/scans/in,/scans/out" or "s3:my-bucket,incoming/".
+func New(spec string) (Backend, error) {
+	return newFromSpec(spec)
+}
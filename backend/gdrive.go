@@ -0,0 +1,302 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v2"
+)
+
+// GDrive talks to the "Scanner"/"Processed" folder pair in Google Drive,
+// the original (and still default) home of docsheep's inbox.
+type GDrive struct {
+	ctx         context.Context
+	client      *http.Client
+	srv         *drive.Service
+	scannerID   string
+	processedID string
+
+	// nameFilter, if set via SetNameFilter, restricts ListIncoming to a
+	// single named file and drops the starred = false term so an
+	// already-"done" (starred) file can be picked for reprocessing.
+	nameFilter string
+}
+
+// NewGDrive authenticates against Google Drive using client_secret.json
+// (prompting for an OAuth code on first run, then caching the token under
+// ~/.credentials) and locates the Scanner/Processed folder pair.
+func NewGDrive() (*GDrive, error) {
+	ctx := context.Background()
+
+	b, err := ioutil.ReadFile("client_secret.json")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client secret file: %v", err)
+	}
+	config, err := google.ConfigFromJSON(b, drive.DriveScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
+	}
+	client := getClient(ctx, config)
+
+	srv, err := drive.New(client)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve drive client: %v", err)
+	}
+
+	r, err := srv.Files.List().Q("mimeType = 'application/vnd.google-apps.folder' and title = 'Scanner'").MaxResults(2).Do()
+	if err != nil || len(r.Items) != 1 {
+		return nil, fmt.Errorf("no Scanner folder (%v, err=%v)", r, err)
+	}
+	scannerID := r.Items[0].Id
+	log.Printf("Scanner folder: %s\n", scannerID)
+
+	query := fmt.Sprintf("'%s' in parents and mimeType = 'application/vnd.google-apps.folder' and title = 'Processed'", scannerID)
+	r, err = srv.Files.List().Q(query).MaxResults(2).Do()
+	if err != nil || len(r.Items) != 1 {
+		return nil, fmt.Errorf("no Processed folder (%v, err=%v)", r, err)
+	}
+	processedID := r.Items[0].Id
+	log.Printf("Processed folder: %s\n", processedID)
+
+	return &GDrive{ctx: ctx, client: client, srv: srv, scannerID: scannerID, processedID: processedID}, nil
+}
+
+// gdrivePollInterval is how often Watch polls the Changes API. Drive v2
+// also supports a push notification channel, but that needs a public
+// callback URL, so polling is the simpler default.
+const gdrivePollInterval = 30 * time.Second
+
+// Watch polls Drive's Changes API for new, non-trashed PDFs that appear
+// under the Scanner folder and reports them as Items.
+func (g *GDrive) Watch(ctx context.Context, out chan<- Item) error {
+	about, err := g.srv.About.Get().Do()
+	if err != nil {
+		return fmt.Errorf("gdrive: About.Get: %v", err)
+	}
+	startChangeID := about.LargestChangeId
+
+	ticker := time.NewTicker(gdrivePollInterval)
+	defer ticker.Stop()
+	for {
+		changes, err := g.srv.Changes.List().StartChangeId(startChangeID + 1).Do()
+		if err != nil {
+			log.Printf("gdrive: Changes.List: %v", err)
+		} else {
+			for _, c := range changes.Items {
+				startChangeID = c.Id
+				if err := g.emitIfIncoming(c, out, ctx); err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (g *GDrive) emitIfIncoming(c *drive.Change, out chan<- Item, ctx context.Context) error {
+	f := c.File
+	if c.Deleted || f == nil || f.MimeType != "application/pdf" {
+		return nil
+	}
+	if f.Labels != nil && (f.Labels.Trashed || f.Labels.Starred) {
+		return nil
+	}
+	inScanner := false
+	for _, p := range f.Parents {
+		if p.Id == g.scannerID {
+			inScanner = true
+			break
+		}
+	}
+	if !inScanner {
+		return nil
+	}
+
+	created, _ := parseDriveTime(f.CreatedDate)
+	select {
+	case out <- Item{ID: f.Id, Name: f.Title, CreatedAt: created}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// SetNameFilter restricts ListIncoming to the single file named name,
+// dropping the starred = false term so a file already marked done by
+// MarkDone can still be picked for reprocessing. An empty name clears
+// the filter and restores the default "unstarred, not trashed" listing.
+func (g *GDrive) SetNameFilter(name string) {
+	g.nameFilter = name
+}
+
+func (g *GDrive) ListIncoming() ([]Item, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false and mimeType = 'application/pdf'", g.scannerID)
+	if g.nameFilter != "" {
+		query += fmt.Sprintf(" and title = '%s'", strings.ReplaceAll(g.nameFilter, "'", "\\'"))
+	} else {
+		query += " and starred = false"
+	}
+	r, err := g.srv.Files.List().Q(query).MaxResults(100).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve files: %v", err)
+	}
+
+	items := make([]Item, 0, len(r.Items))
+	for _, f := range r.Items {
+		if f.DownloadUrl == "" {
+			continue
+		}
+		created, _ := parseDriveTime(f.CreatedDate)
+		items = append(items, Item{ID: f.Id, Name: f.Title, CreatedAt: created})
+	}
+	return items, nil
+}
+
+func (g *GDrive) Fetch(item Item) (string, error) {
+	f, err := g.srv.Files.Get(item.ID).Do()
+	if err != nil {
+		return "", fmt.Errorf("unable to look up %s: %v", item.ID, err)
+	}
+	resp, err := g.client.Get(f.DownloadUrl)
+	if err != nil {
+		return "", fmt.Errorf("unable to download %s: %v", item.ID, err)
+	}
+	defer resp.Body.Close()
+
+	rawPdf := item.ID + ".pdf"
+	out, err := os.Create(rawPdf)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return "", err
+	}
+	return rawPdf, nil
+}
+
+func (g *GDrive) PutProcessed(title, description, localPDFPath string) (string, error) {
+	pdfFile, err := os.Open(localPDFPath)
+	if err != nil {
+		return "", err
+	}
+	defer pdfFile.Close()
+
+	fileMeta := &drive.File{Title: title, Description: description, MimeType: "application/pdf"}
+	fileMeta.Parents = []*drive.ParentReference{{Id: g.processedID}}
+	inserted, err := g.srv.Files.Insert(fileMeta).Media(pdfFile).Ocr(false).Do()
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Inserted: %s %s %s", inserted.Id, inserted.AlternateLink, title)
+	return inserted.Id, nil
+}
+
+func (g *GDrive) MarkDone(item Item) error {
+	addStar := &drive.File{Labels: &drive.FileLabels{Starred: true}}
+	_, err := g.srv.Files.Patch(item.ID, addStar).Do()
+	return err
+}
+
+// parseDriveTime parses Drive's RFC3339 createdDate, treating a parse
+// failure as "unknown" rather than an error worth aborting over.
+func parseDriveTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return t, nil
+}
+
+// getClient uses a Context and Config to retrieve a Token then generate
+// a Client. It returns the generated Client.
+func getClient(ctx context.Context, config *oauth2.Config) *http.Client {
+	cacheFile, err := tokenCacheFile()
+	if err != nil {
+		log.Fatalf("Unable to get path to cached credential file. %v", err)
+	}
+	tok, err := tokenFromFile(cacheFile)
+	if err != nil {
+		tok = getTokenFromWeb(config)
+		saveToken(cacheFile, tok)
+	}
+	return config.Client(ctx, tok)
+}
+
+// getTokenFromWeb uses Config to request a Token.
+// It returns the retrieved Token.
+func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser then type the "+
+		"authorization code: \n%v\n", authURL)
+
+	var code string
+	if _, err := fmt.Scan(&code); err != nil {
+		log.Fatalf("Unable to read authorization code %v", err)
+	}
+
+	tok, err := config.Exchange(oauth2.NoContext, code)
+	if err != nil {
+		log.Fatalf("Unable to retrieve token from web %v", err)
+	}
+	return tok
+}
+
+// tokenCacheFile generates credential file path/filename.
+// It returns the generated credential path/filename.
+func tokenCacheFile() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	tokenCacheDir := filepath.Join(usr.HomeDir, ".credentials")
+	os.MkdirAll(tokenCacheDir, 0700)
+	return filepath.Join(tokenCacheDir,
+		url.QueryEscape("drive-api-quickstart.json")), err
+}
+
+// tokenFromFile retrieves a Token from a given file path.
+// It returns the retrieved Token and any read error encountered.
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	t := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(t)
+	defer f.Close()
+	return t, err
+}
+
+// saveToken uses a file path to create a file and store the
+// token in it.
+func saveToken(file string, token *oauth2.Token) {
+	fmt.Printf("Saving credential file to: %s\n", file)
+	f, err := os.Create(file)
+	if err != nil {
+		log.Fatalf("Unable to cache oauth token: %v", err)
+	}
+	defer f.Close()
+	json.NewEncoder(f).Encode(token)
+}
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+)
+
+// Local watches an "in" directory for PDFs and writes processed output
+// into an "out" directory. It is backed by afero.Fs rather than the os
+// package directly so tests can swap in an in-memory filesystem.
+type Local struct {
+	fs     afero.Fs
+	inDir  string
+	outDir string
+}
+
+// NewLocal builds a Local backend rooted at inDir/outDir on the real
+// filesystem.
+func NewLocal(inDir, outDir string) *Local {
+	return NewLocalFs(afero.NewOsFs(), inDir, outDir)
+}
+
+// NewLocalFs builds a Local backend over an arbitrary afero.Fs, letting
+// tests use afero.NewMemMapFs() instead of touching disk.
+func NewLocalFs(fs afero.Fs, inDir, outDir string) *Local {
+	return &Local{fs: fs, inDir: inDir, outDir: outDir}
+}
+
+func (l *Local) ListIncoming() ([]Item, error) {
+	entries, err := afero.ReadDir(l.fs, l.inDir)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || strings.ToLower(filepath.Ext(e.Name())) != ".pdf" {
+			continue
+		}
+		items = append(items, Item{ID: e.Name(), Name: e.Name(), CreatedAt: e.ModTime()})
+	}
+	return items, nil
+}
+
+// Watch uses fsnotify to report PDFs as they are written into inDir,
+// instead of requiring -watch mode to poll ListIncoming. It only works
+// against the real filesystem (fsnotify can't watch a MemMapFs), so it
+// returns an error if fs isn't an afero.OsFs.
+func (l *Local) Watch(ctx context.Context, out chan<- Item) error {
+	if _, ok := l.fs.(*afero.OsFs); !ok {
+		return fmt.Errorf("local: Watch requires the real filesystem, got %T", l.fs)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("local: fsnotify: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(l.inDir); err != nil {
+		return fmt.Errorf("local: watch %s: %v", l.inDir, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-watcher.Errors:
+			log.Printf("local: watch error: %v", err)
+		case event := <-watcher.Events:
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			if strings.ToLower(filepath.Ext(event.Name)) != ".pdf" {
+				continue
+			}
+			info, err := os.Stat(event.Name)
+			if err != nil {
+				continue
+			}
+			name := filepath.Base(event.Name)
+			select {
+			case out <- Item{ID: name, Name: name, CreatedAt: info.ModTime()}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Fetch copies the incoming PDF out to a real temp file, since the rest
+// of the OCR pipeline (ghostscript, tesseract) shells out and needs a
+// path on the actual filesystem even when fs is an in-memory afero.Fs.
+func (l *Local) Fetch(item Item) (string, error) {
+	src, err := l.fs.Open(filepath.Join(l.inDir, item.Name))
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "docsheep-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func (l *Local) PutProcessed(title, description, localPDFPath string) (string, error) {
+	name := sanitizeFilename(title) + ".pdf"
+	dst, err := l.fs.Create(filepath.Join(l.outDir, name))
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	src, err := os.Open(localPDFPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return "", err
+	}
+
+	metaName := sanitizeFilename(title) + ".txt"
+	if meta, err := l.fs.Create(filepath.Join(l.outDir, metaName)); err == nil {
+		defer meta.Close()
+		io.WriteString(meta, description)
+	}
+	return name, nil
+}
+
+// MarkDone removes the source PDF from the incoming directory so it is
+// not picked up again by a later ListIncoming.
+func (l *Local) MarkDone(item Item) error {
+	return l.fs.Remove(filepath.Join(l.inDir, item.Name))
+}
+
+func sanitizeFilename(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "untitled"
+	}
+	replacer := strings.NewReplacer("/", "-", "\\", "-", ":", "-")
+	return replacer.Replace(name)
+}
@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLocalListIncomingFiltersNonPDFs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	l := NewLocalFs(fs, "/in", "/out")
+
+	afero.WriteFile(fs, "/in/a.pdf", []byte("pdf-a"), 0644)
+	afero.WriteFile(fs, "/in/b.PDF", []byte("pdf-b"), 0644)
+	afero.WriteFile(fs, "/in/notes.txt", []byte("not a pdf"), 0644)
+	fs.MkdirAll("/in/subdir.pdf", 0755)
+
+	items, err := l.ListIncoming()
+	if err != nil {
+		t.Fatalf("ListIncoming: %v", err)
+	}
+	names := map[string]bool{}
+	for _, it := range items {
+		names[it.Name] = true
+	}
+	if len(items) != 2 || !names["a.pdf"] || !names["b.PDF"] {
+		t.Fatalf("ListIncoming = %v, want exactly a.pdf and b.PDF", items)
+	}
+}
+
+func TestLocalPutProcessedAndMarkDone(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	l := NewLocalFs(fs, "/in", "/out")
+	afero.WriteFile(fs, "/in/scan.pdf", []byte("scan"), 0644)
+
+	srcPDF := filepath.Join(t.TempDir(), "rendered.pdf")
+	if err := os.WriteFile(srcPDF, []byte("rendered-pdf"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	name, err := l.PutProcessed("My Title", "description text", srcPDF)
+	if err != nil {
+		t.Fatalf("PutProcessed: %v", err)
+	}
+	if name != "My Title.pdf" {
+		t.Fatalf("PutProcessed name = %q, want %q", name, "My Title.pdf")
+	}
+	if got, err := afero.ReadFile(fs, "/out/My Title.pdf"); err != nil || string(got) != "rendered-pdf" {
+		t.Fatalf("/out/My Title.pdf = %q, %v", got, err)
+	}
+	if got, err := afero.ReadFile(fs, "/out/My Title.txt"); err != nil || string(got) != "description text" {
+		t.Fatalf("/out/My Title.txt = %q, %v", got, err)
+	}
+
+	if err := l.MarkDone(Item{Name: "scan.pdf"}); err != nil {
+		t.Fatalf("MarkDone: %v", err)
+	}
+	if exists, _ := afero.Exists(fs, "/in/scan.pdf"); exists {
+		t.Fatal("MarkDone left /in/scan.pdf behind")
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := map[string]string{
+		"Invoice 2024/05": "Invoice 2024-05",
+		"  spaced  ":      "spaced",
+		"":                "untitled",
+		"a\\b:c":          "a-b-c",
+	}
+	for in, want := range cases {
+		if got := sanitizeFilename(in); got != want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
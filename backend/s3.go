@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3 treats a single bucket/prefix as the inbox and writes processed
+// files alongside it under prefix+"processed/". Scanned PDFs are listed
+// directly (S3 has no folders to watch, so docsheep relies on object
+// keys rather than a separate "Processed" container the way gdrive does).
+type S3 struct {
+	bucket     string
+	prefix     string
+	sess       *session.Session
+	client     *s3.S3
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
+
+// NewS3 builds an S3 backend over bucket, scoping all keys under prefix.
+func NewS3(bucket, prefix string) *S3 {
+	sess := session.Must(session.NewSession())
+	return &S3{
+		bucket:     bucket,
+		prefix:     strings.TrimSuffix(prefix, "/"),
+		sess:       sess,
+		client:     s3.New(sess),
+		uploader:   s3manager.NewUploader(sess),
+		downloader: s3manager.NewDownloader(sess),
+	}
+}
+
+func (s *S3) ListIncoming() ([]Item, error) {
+	out, err := s.client.ListObjects(&s3.ListObjectsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + "/"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3: list %s/%s: %v", s.bucket, s.prefix, err)
+	}
+	items := make([]Item, 0, len(out.Contents))
+	for _, obj := range out.Contents {
+		key := aws.StringValue(obj.Key)
+		if strings.HasPrefix(key, s.prefix+"/processed/") || strings.ToLower(path.Ext(key)) != ".pdf" {
+			continue
+		}
+		items = append(items, Item{ID: key, Name: path.Base(key), CreatedAt: aws.TimeValue(obj.LastModified)})
+	}
+	return items, nil
+}
+
+func (s *S3) Fetch(item Item) (string, error) {
+	tmp, err := os.CreateTemp("", "docsheep-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	_, err = s.downloader.Download(tmp, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(item.ID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3: download %s: %v", item.ID, err)
+	}
+	return tmp.Name(), nil
+}
+
+func (s *S3) PutProcessed(title, description, localPDFPath string) (string, error) {
+	f, err := os.Open(localPDFPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	key := s.prefix + "/processed/" + sanitizeFilename(title) + ".pdf"
+	_, err = s.uploader.Upload(&s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        f,
+		Metadata:    map[string]*string{"docsheep-description": aws.String(description)},
+		ContentType: aws.String("application/pdf"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3: upload %s: %v", key, err)
+	}
+	return key, nil
+}
+
+// MarkDone deletes the source object once it has been processed, since
+// S3 has no "starred" flag to toggle the way Drive does.
+func (s *S3) MarkDone(item Item) error {
+	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(item.ID),
+	})
+	return err
+}
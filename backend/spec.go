@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// newFromSpec parses a "--backend" flag value and builds the matching
+// Backend. The part before the first ':' selects the driver; anything
+// after it is driver-specific.
+func newFromSpec(spec string) (Backend, error) {
+	driver, rest := spec, ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		driver, rest = spec[:i], spec[i+1:]
+	}
+
+	switch driver {
+	case "gdrive":
+		return NewGDrive()
+	case "local":
+		parts := strings.SplitN(rest, ",", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("backend: local needs \"in,out\" directories, got %q", rest)
+		}
+		return NewLocal(parts[0], parts[1]), nil
+	case "s3":
+		parts := strings.SplitN(rest, ",", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("backend: s3 needs \"bucket,prefix\", got %q", rest)
+		}
+		return NewS3(parts[0], parts[1]), nil
+	default:
+		return nil, fmt.Errorf("backend: unknown driver %q", driver)
+	}
+}
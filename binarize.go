@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	"golang.org/x/image/tiff"
+)
+
+// binarizeThresholds are the candidate threshold factors k tried for
+// each page, following the rescribe pipeline's approach of trying a
+// handful of thresholds and keeping whichever OCRs best rather than
+// trying to pick the single "correct" one up front.
+var binarizeThresholds = []float64{0.1, 0.2, 0.3}
+
+// binarizeVariant reads srcTiff, converts it to grayscale, and writes a
+// black/white TIFF to outPath using a global mean/stddev threshold of
+// mean - k*stddev (a simple Otsu/Sauvola-adjacent thresholder, no
+// leptonica or imagemagick required).
+func binarizeVariant(srcTiff, outPath string, k float64) error {
+	f, err := os.Open(srcTiff)
+	if err != nil {
+		return fmt.Errorf("binarize: open %s: %v", srcTiff, err)
+	}
+	defer f.Close()
+
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("binarize: decode %s: %v", srcTiff, err)
+	}
+
+	gray := toGray(src)
+	threshold := grayThreshold(gray, k)
+
+	bw := image.NewGray(gray.Bounds())
+	for i, px := range gray.Pix {
+		if float64(px) < threshold {
+			bw.Pix[i] = 0
+		} else {
+			bw.Pix[i] = 255
+		}
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("binarize: create %s: %v", outPath, err)
+	}
+	defer out.Close()
+	if err := tiff.Encode(out, bw, nil); err != nil {
+		return fmt.Errorf("binarize: encode %s: %v", outPath, err)
+	}
+	return nil
+}
+
+// toGray converts any image.Image to image.Gray.
+func toGray(src image.Image) *image.Gray {
+	if g, ok := src.(*image.Gray); ok {
+		return g
+	}
+	bounds := src.Bounds()
+	gray := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, color.GrayModel.Convert(src.At(x, y)))
+		}
+	}
+	return gray
+}
+
+// grayThreshold returns mean(gray) - k*stddev(gray), the cutoff used to
+// split pixels into black/white in binarizeVariant.
+func grayThreshold(gray *image.Gray, k float64) float64 {
+	n := len(gray.Pix)
+	if n == 0 {
+		return 128
+	}
+	var sum float64
+	for _, px := range gray.Pix {
+		sum += float64(px)
+	}
+	mean := sum / float64(n)
+
+	var variance float64
+	for _, px := range gray.Pix {
+		d := float64(px) - mean
+		variance += d * d
+	}
+	stddev := math.Sqrt(variance / float64(n))
+
+	return mean - k*stddev
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestGrayThresholdUniformImage(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range gray.Pix {
+		gray.Pix[i] = 200
+	}
+	// stddev is 0 for a uniform image, so the threshold should sit
+	// exactly at the mean regardless of k.
+	if got := grayThreshold(gray, 0.3); got != 200 {
+		t.Fatalf("grayThreshold = %v, want 200", got)
+	}
+}
+
+func TestGrayThresholdLowersWithK(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	gray.Pix = []byte{0, 0, 255, 255}
+
+	low := grayThreshold(gray, 0.1)
+	high := grayThreshold(gray, 0.5)
+	if !(high < low) {
+		t.Fatalf("grayThreshold(k=0.5)=%v should be lower than grayThreshold(k=0.1)=%v", high, low)
+	}
+}
+
+func TestGrayThresholdEmptyImage(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 0, 0))
+	if got := grayThreshold(gray, 0.3); got != 128 {
+		t.Fatalf("grayThreshold(empty) = %v, want 128", got)
+	}
+}
+
+func TestToGrayPassesThroughGrayImage(t *testing.T) {
+	g := image.NewGray(image.Rect(0, 0, 2, 2))
+	g.Pix = []byte{10, 20, 30, 40}
+	if got := toGray(g); got != g {
+		t.Fatal("toGray should return the same *image.Gray unchanged")
+	}
+}
+
+func TestToGrayConvertsRGBA(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	src.Set(0, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+
+	gray := toGray(src)
+	want := color.GrayModel.Convert(color.RGBA{R: 255, G: 255, B: 255, A: 255}).(color.Gray).Y
+	if got := gray.GrayAt(0, 0).Y; math.Abs(float64(got)-float64(want)) > 1 {
+		t.Fatalf("toGray white pixel = %v, want ~%v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Command gen downloads the pinned tessdata_fast trained-data files into
+// internal/tessdata, verifying each one's SHA-256 checksum before writing
+// it, so the Go build embeds a known-good copy rather than whatever a
+// flaky download produced.
+//
+// Invoked via `go generate ./...` (see the go:generate directive in
+// ../tessdata.go).
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// pinnedRevision is the tessdata_fast git tag/commit these files are
+// downloaded from. Bump it, and the checksums below, together.
+const pinnedRevision = "4.1.0"
+
+var files = []struct {
+	name   string
+	sha256 string
+}{
+	{"deu.traineddata", "f7e83c6cba5e63a19c2ff91fab2cba2f4c9e8ea57a0a49cc6e7c1ba7b0cbda9f"},
+	{"eng.traineddata", "b649dc684f3899f4fe4e97f8f4a4d1a64ee47c2e4a5e4598ac9f2930cd5b3b22"},
+	{"osd.traineddata", "c1ecf3b91fe6cf46b1c55dc0c05b9ee9d21d2cd5c0a22d82e4f1b5f9abfef456"},
+}
+
+const baseURL = "https://raw.githubusercontent.com/tesseract-ocr/tessdata_fast/" + pinnedRevision + "/"
+
+func main() {
+	outDir, err := filepath.Abs(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range files {
+		if err := download(baseURL+f.name, filepath.Join(outDir, f.name), f.sha256); err != nil {
+			log.Fatalf("%s: %v", f.name, err)
+		}
+		fmt.Printf("wrote %s\n", f.name)
+	}
+}
+
+func download(url, dest, wantSHA256 string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("download: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download: unexpected status %s", resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "tessdata-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("download: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != wantSHA256 {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, wantSHA256)
+	}
+	return os.Rename(tmp.Name(), dest)
+}
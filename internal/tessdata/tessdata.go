@@ -0,0 +1,14 @@
+// Package tessdata embeds the trained-data files docsheep needs so the
+// binary runs on a machine with no system tesseract install.
+//
+// The embedded files are not checked in by hand: run `go generate ./...`
+// to (re)download the pinned tessdata_fast models into this directory,
+// verifying their SHA-256 checksums first.
+package tessdata
+
+import "embed"
+
+//go:generate go run ./gen
+
+//go:embed deu.traineddata eng.traineddata osd.traineddata
+var FS embed.FS
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/GeertJohan/go.leptonica.v1"
+
+	"github.com/unbe/docsheep/backend"
+)
+
+var rotateHint = regexp.MustCompile("_rotate([0-9]+)")
+
+// Processor runs the rasterize -> OCR -> upload pipeline against a
+// single Backend, so the same code path serves Drive, a local inbox, or
+// S3 without caring which one it was handed.
+type Processor struct {
+	Backend backend.Backend
+	Engine  *TessEngine
+
+	// NewEngine builds a fresh TessEngine for a RunWatch worker.
+	// TessBaseAPI isn't safe for concurrent use from multiple
+	// goroutines, so each worker gets its own handle instead of sharing
+	// Engine; Run (the non-watch, single-goroutine path) still just
+	// uses Engine directly.
+	NewEngine func() (*TessEngine, error)
+
+	// NameFilter, if non-empty, restricts Run to the single item whose
+	// Name matches exactly (the old quickstart "pass one file" mode).
+	NameFilter string
+
+	// OSDMinConfidence is the minimum orientation-detection confidence
+	// Tesseract's OSD pass must report before its answer is trusted. Below
+	// it, processOne falls back to brute-forcing all four rotations.
+	OSDMinConfidence float32
+}
+
+// defaultOSDMinConfidence is conservative: Tesseract's OSD confidence is
+// roughly 0 (no idea) upward, and below 1 the orientation guess is not
+// much better than chance.
+const defaultOSDMinConfidence = 1.0
+
+// NewProcessor builds a Processor for the given backend, recognizing
+// pages with engine (which is shared across every page processed).
+func NewProcessor(b backend.Backend, engine *TessEngine) *Processor {
+	return &Processor{Backend: b, Engine: engine, OSDMinConfidence: defaultOSDMinConfidence}
+}
+
+// nameFilterable is implemented by backends (gdrive) that can narrow
+// ListIncoming to a single named file server-side, and that need to
+// relax other filtering (e.g. Drive's "starred = false") to let an
+// already-processed file be reprocessed on request.
+type nameFilterable interface {
+	SetNameFilter(name string)
+}
+
+// Run processes every currently-pending item once and returns.
+func (p *Processor) Run() error {
+	if nf, ok := p.Backend.(nameFilterable); ok {
+		nf.SetNameFilter(p.NameFilter)
+	}
+	items, err := p.Backend.ListIncoming()
+	if err != nil {
+		return fmt.Errorf("list incoming: %v", err)
+	}
+	for _, item := range items {
+		if p.NameFilter != "" && item.Name != p.NameFilter {
+			continue
+		}
+		if err := p.processOne(item); err != nil {
+			log.Printf("Failed to process %s: %v", item.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Processor) processOne(item backend.Item) error {
+	log.Printf("%s (%s)\n", item.Name, item.ID)
+
+	rawPdf, err := p.Backend.Fetch(item)
+	if err != nil {
+		return fmt.Errorf("fetch: %v", err)
+	}
+	defer os.Remove(rawPdf)
+
+	tiffFile := item.ID + ".tiff"
+	gsCmd := exec.Command(
+		"gs", "-dNumRenderingThreads=4", "-dINTERPOLATE", "-sDEVICE=tiff24nc", "-r300",
+		"-o", tiffFile, "-c", "100000000", "setvmthreshold", "-f", rawPdf)
+	log.Printf("Running ghostscript: %v\n", gsCmd.Args)
+	if err := gsCmd.Run(); err != nil {
+		return fmt.Errorf("ghostscript: %v", err)
+	}
+	defer os.Remove(tiffFile)
+
+	basePix, err := leptonica.NewPixFromFile(tiffFile)
+	if err != nil {
+		return fmt.Errorf("load page: %v", err)
+	}
+	defer basePix.Close()
+
+	var title, outputPrefix string
+	rotations := []int{0, 180, 90, 270}
+	if m := rotateHint.FindStringSubmatch(item.Name); len(m) == 2 {
+		// Explicit user hint in the filename always wins; skip OSD entirely.
+		requestedAngle, _ := strconv.Atoi(m[1])
+		rotations = []int{requestedAngle}
+	} else if osd, err := p.Engine.DetectOrientation(basePix); err != nil {
+		log.Printf("OSD failed, falling back to brute-force rotation sweep: %v", err)
+	} else if osd.Confidence >= p.OSDMinConfidence {
+		log.Printf("OSD detected %d° rotation (confidence %.2f, script %s)", osd.Orientation, osd.Confidence, osd.Script)
+		rotations = []int{osd.Orientation}
+	}
+
+	bestConf := -1.0
+
+angleLoop:
+	for _, angle := range rotations {
+		ocrInput := fmt.Sprintf("%s-r%d.tiff", tiffFile, angle)
+		if angle == 0 {
+			if err := basePix.WriteFile(ocrInput, leptonica.TIFF); err != nil {
+				return fmt.Errorf("write page: %v", err)
+			}
+		} else if err := RotatePix(basePix, angle, ocrInput); err != nil {
+			return fmt.Errorf("rotate %d: %v", angle, err)
+		}
+		defer os.Remove(ocrInput)
+		outputPrefixR := "ocr-" + item.ID + "-r" + strconv.Itoa(angle)
+
+		for _, k := range binarizeThresholds {
+			binInput := fmt.Sprintf("%s-k%.1f.tiff", ocrInput, k)
+			if err := binarizeVariant(ocrInput, binInput, k); err != nil {
+				return fmt.Errorf("binarize k=%.1f: %v", k, err)
+			}
+			defer os.Remove(binInput)
+			outputPrefixK := fmt.Sprintf("%s-k%.1f", outputPrefixR, k)
+
+			titleK, confScore, err := ocrImage(p.Engine, binInput, outputPrefixK)
+			if err != nil {
+				return fmt.Errorf("ocr angle=%d k=%.1f: %v", angle, k, err)
+			}
+			log.Printf("Confidence: %f for title %s (angle=%d, k=%.1f)", confScore, titleK, angle, k)
+			if confScore > bestConf {
+				bestConf = confScore
+				outputPrefix = outputPrefixK
+				title = titleK
+			}
+			if confScore > 70 {
+				break angleLoop
+			}
+		}
+	}
+
+	ocrText, err := ioutil.ReadFile(outputPrefix + ".txt")
+	if err != nil {
+		return fmt.Errorf("read ocr text: %v", err)
+	}
+	descr := string(ocrText) + "\nSource: " + item.Name + " " + item.ID
+	if _, err := p.Backend.PutProcessed(title, descr, outputPrefix+".pdf"); err != nil {
+		return fmt.Errorf("put processed: %v", err)
+	}
+	return p.Backend.MarkDone(item)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	embeddedtessdata "github.com/unbe/docsheep/internal/tessdata"
+)
+
+// ensureTessdata returns a TESSDATA_PREFIX that has deu/eng/osd
+// traineddata available under it. If prefix/tessdata doesn't already
+// have them (no system tesseract install), it extracts docsheep's own
+// embedded copies to a temp directory and returns that instead.
+func ensureTessdata(prefix string) (string, error) {
+	if _, err := os.Stat(filepath.Join(prefix, "tessdata", "eng.traineddata")); err == nil {
+		return prefix, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "docsheep-tessdata-")
+	if err != nil {
+		return "", err
+	}
+	tessdataDir := filepath.Join(tmpDir, "tessdata")
+	if err := os.MkdirAll(tessdataDir, 0755); err != nil {
+		return "", err
+	}
+
+	entries, err := embeddedtessdata.FS.ReadDir(".")
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		data, err := embeddedtessdata.FS.ReadFile(e.Name())
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(tessdataDir, e.Name()), data, 0644); err != nil {
+			return "", err
+		}
+	}
+	log.Printf("No system tessdata found under %s; extracted the embedded copy to %s\n", prefix, tmpDir)
+	return tmpDir, nil
+}
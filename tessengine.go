@@ -0,0 +1,229 @@
+package main
+
+// #cgo LDFLAGS: -L /usr/local/lib -ltesseract -lleptonica
+// #include "tesseract/capi.h"
+// #include <leptonica/allheaders.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/unbe/go.tesseract"
+	"gopkg.in/GeertJohan/go.leptonica.v1"
+)
+
+// TessWord is one recognized word together with the font metadata
+// tesseract's result iterator exposes, which the hOCR output on its own
+// cannot carry reliably (pointsize, font_id and bold/italic/serif are
+// all read straight off the TessResultIterator here).
+type TessWord struct {
+	Text         string
+	Confidence   float32
+	IsBold       bool
+	IsItalic     bool
+	IsUnderlined bool
+	IsMonospace  bool
+	IsSerif      bool
+	IsSmallcaps  bool
+	Pointsize    int
+	FontID       int
+	FontName     string
+}
+
+// TessEngine owns a single TessBaseAPI handle that is initialized once
+// and reused across pages (SetImagePix per page), instead of forking a
+// fresh `tesseract` process for every scan.
+type TessEngine struct {
+	t *tesseract.Tess
+}
+
+// NewTessEngine initializes a TessBaseAPI against tessdataPrefix/tessdata
+// for the given language string (e.g. "deu+eng").
+func NewTessEngine(tessdataPrefix, lang string) (*TessEngine, error) {
+	t, err := tesseract.NewTess(filepath.Join(tessdataPrefix, "tessdata"), lang)
+	if err != nil {
+		return nil, fmt.Errorf("tessengine: init: %v", err)
+	}
+	return &TessEngine{t: t}, nil
+}
+
+// Close releases the underlying TessBaseAPI handle.
+func (e *TessEngine) Close() {
+	e.t.Close()
+}
+
+// Text returns the plain-text recognition result for the page most
+// recently passed to RecognizePage.
+func (e *TessEngine) Text() string {
+	return e.t.Text()
+}
+
+// OSDResult is the outcome of an orientation/script detection pass.
+type OSDResult struct {
+	// Orientation is the clockwise rotation, in degrees, needed to make
+	// the page upright (one of 0, 90, 180, 270).
+	Orientation int
+	Confidence  float32
+	Script      string
+}
+
+// DetectOrientation runs Tesseract's OSD-only page segmentation mode on
+// pix and returns the rotation needed to make it upright, so the hot
+// loop can OCR once at that angle instead of brute-forcing all four.
+func (e *TessEngine) DetectOrientation(pix *leptonica.Pix) (OSDResult, error) {
+	e.t.SetPageSegMode(tesseract.PSM_OSD_ONLY)
+	defer e.t.SetPageSegMode(tesseract.PSM_AUTO_OSD)
+	e.t.SetImagePix(pix)
+
+	th := (*C.struct_TessBaseAPI)(e.t.Handle())
+	var orientDeg C.int
+	var orientConf, scriptConf C.float
+	var script *C.char
+
+	ok := C.TessBaseAPIDetectOrientationScript(th, &orientDeg, &orientConf, &script, &scriptConf)
+	if ok == C.FALSE {
+		return OSDResult{}, fmt.Errorf("tessengine: orientation/script detection failed")
+	}
+	defer C.TessDeleteText(script)
+
+	// Tesseract reports the rotation already applied to the input; the
+	// correction we need to apply is the opposite of that.
+	rotation := (360 - int(orientDeg)) % 360
+	return OSDResult{
+		Orientation: rotation,
+		Confidence:  float32(orientConf),
+		Script:      C.GoString(script),
+	}, nil
+}
+
+// RecognizePage runs OCR on pix and returns the per-word results,
+// including the font metadata the result iterator tracks.
+func (e *TessEngine) RecognizePage(pix *leptonica.Pix) ([]TessWord, error) {
+	e.t.SetImagePix(pix)
+	e.t.Text() // runs recognition; result is walked via the iterator below
+
+	th := (*C.struct_TessBaseAPI)(e.t.Handle())
+	ri := C.TessBaseAPIGetIterator(th)
+	if ri == nil {
+		return nil, nil
+	}
+	defer C.TessResultIteratorDelete(ri)
+
+	words := make([]TessWord, 0)
+	pi := C.TessResultIteratorGetPageIterator(ri)
+	for {
+		words = append(words, tessWordFromIterator(ri))
+		if C.TessPageIteratorNext(pi, C.RIL_WORD) == C.int(0) {
+			break
+		}
+	}
+	return words, nil
+}
+
+// HOCRText returns the current page's recognition result as hOCR, the
+// same markup the `tesseract -c tessedit_create_hocr=1` CLI used to
+// write to disk, but generated in-process.
+func (e *TessEngine) HOCRText(pageNum int) (string, error) {
+	th := (*C.struct_TessBaseAPI)(e.t.Handle())
+	cStr := C.TessBaseAPIGetHOCRText(th, C.int(pageNum))
+	if cStr == nil {
+		return "", fmt.Errorf("tessengine: GetHOCRText returned NULL")
+	}
+	defer C.TessDeleteText(cStr)
+	return C.GoString(cStr), nil
+}
+
+// WriteSearchablePDF recognizes pix and appends it to a searchable PDF
+// at outputBase+".pdf", replacing `tesseract -c tessedit_create_pdf=1`.
+func (e *TessEngine) WriteSearchablePDF(pix *leptonica.Pix, outputBase string) error {
+	th := (*C.struct_TessBaseAPI)(e.t.Handle())
+
+	cOutputBase := C.CString(outputBase)
+	defer C.free(unsafe.Pointer(cOutputBase))
+	cDatadir := C.CString("")
+	defer C.free(unsafe.Pointer(cDatadir))
+
+	renderer := C.TessPDFRendererCreate(cOutputBase, cDatadir, C.FALSE)
+	if renderer == nil {
+		return fmt.Errorf("tessengine: could not create PDF renderer")
+	}
+	defer C.TessDeleteResultRenderer(renderer)
+
+	e.t.SetImagePix(pix)
+	if C.TessResultRendererBeginDocument(renderer, cOutputBase) == C.FALSE {
+		return fmt.Errorf("tessengine: BeginDocument failed")
+	}
+	if C.TessResultRendererAddImage(renderer, th) == C.FALSE {
+		return fmt.Errorf("tessengine: AddImage failed")
+	}
+	if C.TessResultRendererEndDocument(renderer) == C.FALSE {
+		return fmt.Errorf("tessengine: EndDocument failed")
+	}
+	return nil
+}
+
+// RotatePix rotates pix by a multiple of 90 degrees in-process using
+// leptonica's pixRotateOrth and writes the result straight to outPath
+// as a TIFF, replacing the `convert -rotate` shell-out. angle must be
+// one of 0, 90, 180, 270.
+//
+// It writes to a file rather than returning a *leptonica.Pix because
+// the vendored go.leptonica.v1 binding has no way to wrap a raw
+// rotated PIX back into one outside its own package (Pix's fields are
+// unexported and it exposes no NewPixFromHandle-style constructor) -
+// only pix.CPIX() to read one out. pixRotateOrth's result is freed
+// here via pixDestroy since it never becomes a *leptonica.Pix that
+// would free it on Close.
+func RotatePix(pix *leptonica.Pix, angle int, outPath string) error {
+	if angle%90 != 0 {
+		return fmt.Errorf("rotatepix: angle %d is not a multiple of 90", angle)
+	}
+	quads := C.l_int32((angle / 90) % 4)
+	rotated := C.pixRotateOrth((*C.PIX)(unsafe.Pointer(pix.CPIX())), quads)
+	if rotated == nil {
+		return fmt.Errorf("rotatepix: pixRotateOrth failed")
+	}
+	defer C.pixDestroy(&rotated)
+
+	cOutPath := C.CString(outPath)
+	defer C.free(unsafe.Pointer(cOutPath))
+	if C.pixWrite(cOutPath, rotated, C.l_int32(leptonica.TIFF)) != 0 {
+		return fmt.Errorf("rotatepix: write %s failed", outPath)
+	}
+	return nil
+}
+
+func tessWordFromIterator(ri *C.struct_TessResultIterator) TessWord {
+	cWord := C.TessResultIteratorGetUTF8Text(ri, C.RIL_WORD)
+	defer C.TessDeleteText(cWord)
+
+	isBold := C.BOOL(0)
+	isItalic := C.BOOL(0)
+	isUnderlined := C.BOOL(0)
+	isMonospace := C.BOOL(0)
+	isSerif := C.BOOL(0)
+	isSmallcaps := C.BOOL(0)
+	pointsize := C.int(0)
+	fontID := C.int(0)
+
+	fontName := C.TessResultIteratorWordFontAttributes(ri, &isBold, &isItalic, &isUnderlined,
+		&isMonospace, &isSerif, &isSmallcaps, &pointsize, &fontID)
+	conf := C.TessResultIteratorConfidence(ri, C.RIL_WORD)
+
+	return TessWord{
+		Text:         C.GoString(cWord),
+		Confidence:   float32(conf),
+		IsBold:       isBold != 0,
+		IsItalic:     isItalic != 0,
+		IsUnderlined: isUnderlined != 0,
+		IsMonospace:  isMonospace != 0,
+		IsSerif:      isSerif != 0,
+		IsSmallcaps:  isSmallcaps != 0,
+		Pointsize:    int(pointsize),
+		FontID:       int(fontID),
+		FontName:     C.GoString(fontName),
+	}
+}
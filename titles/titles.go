@@ -0,0 +1,321 @@
+// Package titles picks a document title out of a page's hOCR output by
+// looking at line structure and font geometry, rather than pooling every
+// word on the page and re-sorting it by weight.
+package titles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Config controls how Extract scores and assembles a title candidate.
+// Per-user junk terms belong here, not hard-coded in this package, since
+// a stoplist tuned for one scanning setup is noise for another.
+type Config struct {
+	// Stoplist holds words (names, addresses, PO box numbers, ...) that
+	// should never end up in a title. Scoring penalizes lines containing
+	// them rather than dropping the words outright, so one stoplist hit
+	// next to real title text doesn't sink the whole line.
+	Stoplist []string
+
+	// TopFraction restricts title candidates to lines whose top position
+	// falls within the first TopFraction of the page height (0 to 1).
+	// Lines further down only get considered if nothing qualifies.
+	TopFraction float64
+
+	// MaxTitleLines caps how many contiguous lines can be merged into
+	// one title candidate.
+	MaxTitleLines int
+
+	// MaxTitleLength caps the assembled title's length in characters.
+	MaxTitleLength int
+
+	// KnownWords is consulted when dehyphenating a line break: a
+	// trailing hyphen always gets joined with the next line's first
+	// word, but a hit here confirms the join produced a real word
+	// rather than an OCR artifact.
+	KnownWords []string
+}
+
+// DefaultConfig mirrors the stoplist and thresholds the original
+// bag-of-words heuristic used.
+func DefaultConfig() Config {
+	return Config{
+		Stoplist: []string{
+			"Mister", "Herr", "Frau", "8052", "8802", "Artem", "Natalia",
+			"Malyshev", "Malyshew", "Malysheva", "Weinbergstrasse", "23",
+			"HÃ¶henring", "Schaffhauserstrasse", "547",
+		},
+		TopFraction:    0.4,
+		MaxTitleLines:  2,
+		MaxTitleLength: 80,
+		KnownWords:     defaultKnownWords,
+	}
+}
+
+// defaultKnownWords is a small German+English dehyphenation dictionary,
+// just enough to confirm common line-break joins.
+var defaultKnownWords = []string{
+	"rechnung", "bestätigung", "vertrag", "versicherung", "bescheinigung",
+	"mitteilung", "information", "anmeldung", "kündigung", "abrechnung",
+	"statement", "confirmation", "agreement", "insurance", "information",
+	"notification", "registration", "cancellation", "invoice",
+}
+
+type word struct {
+	text       string
+	confidence int
+	fontSize   int
+	left, top  int
+}
+
+type line struct {
+	words       []word
+	avgFontSize float64
+	top         int
+}
+
+func (l line) text() string {
+	parts := make([]string, len(l.words))
+	for i, w := range l.words {
+		parts[i] = w.text
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l line) endsHyphenated() bool {
+	if len(l.words) == 0 {
+		return false
+	}
+	return strings.HasSuffix(l.words[len(l.words)-1].text, "-")
+}
+
+// Extract parses a single page's hOCR markup (selected by pageSelector,
+// e.g. "#page_1") and returns the best-scoring title candidate in
+// reading order, dehyphenated, plus the average per-letter confidence
+// across its words.
+func Extract(hocrHTML, pageSelector string, cfg Config) (title string, confScore float64, err error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(hocrHTML))
+	if err != nil {
+		return "", 0, err
+	}
+	page := doc.Find(pageSelector).First()
+	if page.Length() == 0 {
+		return "", 0, fmt.Errorf("titles: no %q in hOCR", pageSelector)
+	}
+	_, _, _, pageHeight := parseBBox(parseProps(page.AttrOr("title", ""))["bbox"])
+
+	stoplist := make(map[string]bool, len(cfg.Stoplist))
+	for _, w := range cfg.Stoplist {
+		stoplist[w] = true
+	}
+	known := make(map[string]bool, len(cfg.KnownWords))
+	for _, w := range cfg.KnownWords {
+		known[strings.ToLower(w)] = true
+	}
+
+	var lines []line
+	page.Find(".ocr_line").Each(func(_ int, s *goquery.Selection) {
+		if l := parseLine(s); len(l.words) > 0 {
+			lines = append(lines, l)
+		}
+	})
+	if len(lines) == 0 {
+		return "", 0, nil
+	}
+
+	best := bestLine(lines, stoplist, pageHeight, cfg.TopFraction)
+	if best < 0 {
+		return "", 0, nil
+	}
+
+	run := lines[best : best+1]
+	for len(run) < cfg.MaxTitleLines {
+		next := best + len(run)
+		if next >= len(lines) || !run[len(run)-1].endsHyphenated() {
+			break
+		}
+		run = lines[best : next+1]
+	}
+
+	return assembleTitle(run, known, cfg.MaxTitleLength)
+}
+
+// parseLine reads the .ocrx_word children of an .ocr_line selection into
+// a line, preserving reading (document) order.
+func parseLine(s *goquery.Selection) line {
+	var l line
+	var fontSizeSum int
+	_, lineTop, _, _ := parseBBox(parseProps(s.AttrOr("title", ""))["bbox"])
+	l.top = lineTop
+
+	s.Find(".ocrx_word").Each(func(_ int, ws *goquery.Selection) {
+		props := parseProps(ws.AttrOr("title", ""))
+		fontSize, _ := strconv.Atoi(props["x_fsize"])
+		confidence, _ := strconv.Atoi(props["x_wconf"])
+		left, top, _, _ := parseBBox(props["bbox"])
+		text := strings.TrimSpace(ws.Text())
+		if text == "" {
+			return
+		}
+		l.words = append(l.words, word{text: text, confidence: confidence, fontSize: fontSize, left: left, top: top})
+		fontSizeSum += fontSize
+	})
+	if len(l.words) > 0 {
+		l.avgFontSize = float64(fontSizeSum) / float64(len(l.words))
+	}
+	return l
+}
+
+// bestLine scores every candidate line and returns the index of the
+// highest-scoring one, preferring lines within the top TopFraction of
+// the page but falling back to the full page if none qualify.
+func bestLine(lines []line, stoplist map[string]bool, pageHeight int, topFraction float64) int {
+	cutoff := int(float64(pageHeight) * topFraction)
+
+	score := func(l line) float64 {
+		s := l.avgFontSize * 10
+		for _, w := range l.words {
+			if stoplist[w.text] {
+				s -= 100
+			}
+		}
+		return s
+	}
+
+	best, bestScore := -1, 0.0
+	for i, l := range lines {
+		if pageHeight > 0 && l.top > cutoff {
+			continue
+		}
+		if s := score(l); best == -1 || s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	if best >= 0 {
+		return best
+	}
+	// Nothing fell within the top fraction; fall back to the whole page.
+	for i, l := range lines {
+		if s := score(l); best == -1 || s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	return best
+}
+
+// assembleTitle joins a run of lines in reading order, dehyphenating
+// line-final words, and tracks the per-letter confidence score the same
+// way the original bag-of-words heuristic did.
+func assembleTitle(run []line, known map[string]bool, maxLen int) (string, float64, error) {
+	var b strings.Builder
+	var confidences []int
+
+	for li, l := range run {
+		words := l.words
+		if li > 0 && len(run[li-1].words) > 0 && run[li-1].endsHyphenated() && len(words) > 0 {
+			prevWord := run[li-1].words[len(run[li-1].words)-1].text
+			joined, ok := dehyphenate(prevWord, words[0].text, known)
+			if ok {
+				// The previous word was already written with its
+				// trailing hyphen; strip it and append the join.
+				s := b.String()
+				b.Reset()
+				b.WriteString(strings.TrimSuffix(s, prevWord+" "))
+				b.WriteString(joined + " ")
+				confidences = append(confidences, countLetterConfidence(words[0])...)
+				words = words[1:]
+			}
+		}
+		for _, w := range words {
+			b.WriteString(w.text + " ")
+			confidences = append(confidences, countLetterConfidence(w)...)
+		}
+		if b.Len() > maxLen {
+			break
+		}
+	}
+
+	title := strings.TrimSpace(b.String())
+	if len(title) > maxLen {
+		title = strings.TrimSpace(title[:maxLen])
+	}
+	return title, avg(confidences), nil
+}
+
+func countLetterConfidence(w word) []int {
+	var confidences []int
+	for _, r := range w.text {
+		if isLetterOrDigit(r) {
+			confidences = append(confidences, w.confidence)
+		}
+	}
+	return confidences
+}
+
+// dehyphenate joins prevWord (ending in "-") with nextWord. It always
+// joins - a trailing hyphen at a recognized line end is itself the
+// signal - but if the straight join isn't in known, it also tries
+// dropping a doubled leading letter off nextWord (Tesseract sometimes
+// echoes the second half's first letter twice across a line break,
+// e.g. "Rech-nnung" instead of "Rech-nung") and prefers that form when
+// known confirms it.
+func dehyphenate(prevWord, nextWord string, known map[string]bool) (string, bool) {
+	if !strings.HasSuffix(prevWord, "-") {
+		return "", false
+	}
+	base := strings.TrimSuffix(prevWord, "-")
+	joined := base + nextWord
+	if known[strings.ToLower(joined)] {
+		return joined, true
+	}
+	if len(nextWord) > 1 && strings.EqualFold(nextWord[:1], nextWord[1:2]) {
+		if deduped := base + nextWord[1:]; known[strings.ToLower(deduped)] {
+			return deduped, true
+		}
+	}
+	return joined, true
+}
+
+func avg(xs []int) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, x := range xs {
+		sum += x
+	}
+	return float64(sum) / float64(len(xs))
+}
+
+func isLetterOrDigit(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r > 127
+}
+
+func parseProps(title string) map[string]string {
+	props := make(map[string]string)
+	for _, prop := range strings.Split(title, ";") {
+		nameValue := strings.SplitN(strings.TrimLeft(prop, " "), " ", 2)
+		if len(nameValue) == 2 {
+			props[nameValue[0]] = nameValue[1]
+		}
+	}
+	return props
+}
+
+// parseBBox reads the hOCR "bbox left top right bottom" property value,
+// e.g. the right-hand side of `bbox 10 20 300 45` from a title attribute.
+func parseBBox(bbox string) (left, top, right, bottom int) {
+	f := strings.Fields(bbox)
+	if len(f) < 4 {
+		return 0, 0, 0, 0
+	}
+	left, _ = strconv.Atoi(f[0])
+	top, _ = strconv.Atoi(f[1])
+	right, _ = strconv.Atoi(f[2])
+	bottom, _ = strconv.Atoi(f[3])
+	return
+}
@@ -0,0 +1,109 @@
+package titles
+
+import "testing"
+
+func hocrPage(lines ...string) string {
+	body := ""
+	for i, l := range lines {
+		body += l
+		_ = i
+	}
+	return `<html><body>
+<div class="ocr_page" id="page_1" title="bbox 0 0 1000 1400">` + body + `</div>
+</body></html>`
+}
+
+func ocrLine(top int, words ...string) string {
+	out := `<span class="ocr_line" title="bbox 10 ` + itoa(top) + ` 900 ` + itoa(top+40) + `">`
+	for _, w := range words {
+		out += `<span class="ocrx_word" title="bbox 10 ` + itoa(top) + ` 100 ` + itoa(top+40) + `; x_wconf 95; x_fsize 20">` + w + `</span>`
+	}
+	out += `</span>`
+	return out
+}
+
+func itoa(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte('0' + n%10)}, b...)
+		n /= 10
+	}
+	if neg {
+		b = append([]byte{'-'}, b...)
+	}
+	return string(b)
+}
+
+func TestExtractPicksTopLineOverStoplistHit(t *testing.T) {
+	hocr := hocrPage(
+		ocrLine(50, "Rechnung", "Nr.", "1234"),
+		ocrLine(600, "Herr", "Artem", "Malyshev"),
+	)
+	cfg := DefaultConfig()
+	title, conf, err := Extract(hocr, "#page_1", cfg)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if title != "Rechnung Nr. 1234" {
+		t.Fatalf("title = %q, want %q", title, "Rechnung Nr. 1234")
+	}
+	if conf <= 0 {
+		t.Fatalf("conf = %v, want > 0", conf)
+	}
+}
+
+func TestExtractMergesHyphenatedLines(t *testing.T) {
+	hocr := hocrPage(
+		ocrLine(50, "Versicherungs-"),
+		ocrLine(90, "bestätigung"),
+	)
+	title, _, err := Extract(hocr, "#page_1", DefaultConfig())
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if title != "Versicherungsbestätigung" {
+		t.Fatalf("title = %q, want %q", title, "Versicherungsbestätigung")
+	}
+}
+
+func TestExtractNoMatchingPage(t *testing.T) {
+	_, _, err := Extract(`<html><body></body></html>`, "#page_1", DefaultConfig())
+	if err == nil {
+		t.Fatal("Extract: want error for missing page selector")
+	}
+}
+
+func TestDehyphenateRequiresTrailingHyphen(t *testing.T) {
+	if _, ok := dehyphenate("Rechnung", "Nr", nil); ok {
+		t.Fatal("dehyphenate: joined a word with no trailing hyphen")
+	}
+}
+
+func TestDehyphenateJoinsOnHyphen(t *testing.T) {
+	joined, ok := dehyphenate("Rech-", "nung", map[string]bool{})
+	if !ok || joined != "Rechnung" {
+		t.Fatalf("dehyphenate = %q, %v, want %q, true", joined, ok, "Rechnung")
+	}
+}
+
+func TestDehyphenatePrefersKnownDedupedForm(t *testing.T) {
+	known := map[string]bool{"rechnung": true}
+	joined, ok := dehyphenate("Rech-", "nnung", known)
+	if !ok || joined != "Rechnung" {
+		t.Fatalf("dehyphenate = %q, %v, want %q, true", joined, ok, "Rechnung")
+	}
+}
+
+func TestDehyphenateFallsBackWhenDedupedFormUnknown(t *testing.T) {
+	joined, ok := dehyphenate("Rech-", "nnung", map[string]bool{})
+	if !ok || joined != "Rechnnung" {
+		t.Fatalf("dehyphenate = %q, %v, want %q, true", joined, ok, "Rechnnung")
+	}
+}
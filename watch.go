@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/unbe/docsheep/backend"
+)
+
+const (
+	// watchPollInterval is how often -watch mode calls ListIncoming for
+	// backends that don't implement backend.Watchable.
+	watchPollInterval = 30 * time.Second
+	maxRetries        = 5
+	initialBackoff    = 2 * time.Second
+)
+
+// RunWatch turns Processor into a long-running daemon: it watches
+// Backend for newly-arrived PDFs (via backend.Watchable when the
+// backend supports it, otherwise by periodically polling ListIncoming)
+// and feeds them to a bounded pool of jobs workers. It returns once ctx
+// is cancelled and every in-flight job has finished.
+//
+// Each worker recognizes pages on its own TessEngine, built via
+// NewEngine, rather than sharing Processor.Engine: TessBaseAPI isn't
+// safe for concurrent use from multiple goroutines, and this is exactly
+// the concurrent pool that would otherwise race on it.
+func (p *Processor) RunWatch(ctx context.Context, jobs int) error {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if p.NewEngine == nil {
+		return fmt.Errorf("watch: Processor.NewEngine must be set so each worker gets its own TessEngine")
+	}
+
+	// A worker whose NewEngine fails is a fatal misconfiguration (bad
+	// TESSDATA_PREFIX, exhausted resources, ...), not a per-item error:
+	// cancel so the producer and the remaining workers stop promptly
+	// instead of idling forever with nothing flowing through items.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan backend.Item)
+	watchDone := make(chan error, 1)
+	go func() {
+		if w, ok := p.Backend.(backend.Watchable); ok {
+			watchDone <- w.Watch(ctx, items)
+		} else {
+			watchDone <- pollForItems(ctx, p.Backend, items)
+		}
+		close(items)
+	}()
+
+	var wg sync.WaitGroup
+	var startErrOnce sync.Once
+	var startErr error
+	for worker := 0; worker < jobs; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			engine, err := p.NewEngine()
+			if err != nil {
+				startErrOnce.Do(func() {
+					startErr = fmt.Errorf("worker %d: unable to start a TessEngine: %v", worker, err)
+					cancel()
+				})
+				log.Printf("[worker %d] unable to start a TessEngine: %v", worker, err)
+				return
+			}
+			defer engine.Close()
+
+			workerProc := *p
+			workerProc.Engine = engine
+			for item := range items {
+				if p.NameFilter != "" && item.Name != p.NameFilter {
+					continue
+				}
+				workerProc.processWithRetry(worker, item)
+			}
+		}(worker)
+	}
+	wg.Wait()
+	watchErr := <-watchDone
+
+	if startErr != nil {
+		return startErr
+	}
+	if watchErr != nil && watchErr != context.Canceled {
+		return watchErr
+	}
+	return nil
+}
+
+// pollForItems is the Watch fallback for backends without a native
+// notification mechanism (e.g. S3): it re-lists periodically and emits
+// whatever IDs it hasn't seen before.
+func pollForItems(ctx context.Context, b backend.Backend, out chan<- backend.Item) error {
+	seen := make(map[string]bool)
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		items, err := b.ListIncoming()
+		if err != nil {
+			log.Printf("watch: poll failed: %v", err)
+		}
+		for _, item := range items {
+			if seen[item.ID] {
+				continue
+			}
+			seen[item.ID] = true
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// processWithRetry runs processOne, retrying transient failures (Drive
+// or other API hiccups) with exponential backoff before giving up on
+// item and moving on to the next one.
+func (p *Processor) processWithRetry(worker int, item backend.Item) {
+	backoff := initialBackoff
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		if err := p.processOne(item); err == nil {
+			log.Printf("[worker %d] %s: done", worker, item.Name)
+			return
+		} else if attempt == maxRetries {
+			log.Printf("[worker %d] %s: giving up after %d attempts: %v", worker, item.Name, maxRetries, err)
+			return
+		} else {
+			log.Printf("[worker %d] %s: attempt %d/%d failed, retrying in %s: %v", worker, item.Name, attempt, maxRetries, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}